@@ -0,0 +1,201 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// get drives the "get" subcommand: a small concurrent load generator. It
+// fans a fixed number of requests out over a worker pool of goroutines,
+// cycling through the given target URLs, and reports a latency histogram
+// plus a breakdown of failures by cause.
+func get(args []string) {
+	fs := flag.NewFlagSet("get", flag.ExitOnError)
+	concurrency := fs.Int("c", 1, "number of concurrent workers")
+	total := fs.Int("n", NUM_REQUEST, "total number of requests to make")
+	file := fs.String("f", "", "file containing one target URL per line")
+	fs.Parse(args)
+
+	urls, err := targetURLs(*file, fs.Args())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if len(urls) == 0 {
+		fmt.Println("missing target, for example: get http://127.0.0.1:3000")
+		return
+	}
+
+	hist := &latencyHistogram{}
+	errs := &errorTaxonomy{}
+	var successes int64
+	var nextURL, remaining atomic.Int64
+	remaining.Store(int64(*total))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for remaining.Add(-1) >= 0 {
+				url := urls[nextURL.Add(1)%int64(len(urls))]
+				reqStart := time.Now()
+				resp, err := client.Get(url)
+				if err != nil {
+					errs.classify(err)
+					continue
+				}
+				_, readErr := io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				hist.add(time.Since(reqStart))
+				if readErr != nil {
+					errs.bodyRead.Add(1)
+					continue
+				}
+				if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+					errs.non2xx.Add(1)
+					continue
+				}
+				atomic.AddInt64(&successes, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	dur := time.Since(start)
+
+	n := int64(*total)
+	fmt.Printf("made %d requests (%d ok) in %s\n%.2freq/sec\n", n, successes, dur, float64(n)/dur.Seconds())
+	hist.print()
+	errs.print()
+}
+
+// targetURLs resolves the list of URLs to hit, either from the newline
+// separated file at path, or from the remaining positional arguments.
+func targetURLs(path string, positional []string) ([]string, error) {
+	if path == "" {
+		return positional, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var urls []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			urls = append(urls, line)
+		}
+	}
+	return urls, nil
+}
+
+// maxHistogramSamples bounds how many samples a latencyHistogram retains.
+// Once full, add overwrites the oldest sample rather than growing the
+// slice, so a long-lived histogram (e.g. one kept per route for the
+// lifetime of a server) can't be grown without bound by request volume.
+const maxHistogramSamples = 10_000
+
+// latencyHistogram accumulates per-request durations and reports
+// min/p50/p90/p99/max on demand. It is safe for concurrent use.
+type latencyHistogram struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func (h *latencyHistogram) add(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.samples) < maxHistogramSamples {
+		h.samples = append(h.samples, d)
+		return
+	}
+	h.samples[h.next] = d
+	h.next = (h.next + 1) % maxHistogramSamples
+}
+
+func (h *latencyHistogram) print() {
+	min, p50, p90, p99, max := h.quantiles()
+	if min == 0 && max == 0 {
+		fmt.Println("no completed requests")
+		return
+	}
+	fmt.Printf("min=%s p50=%s p90=%s p99=%s max=%s\n", min, p50, p90, p99, max)
+}
+
+// quantiles returns the min, p50, p90, p99, and max of the samples
+// collected so far. All values are zero if no samples have been added.
+func (h *latencyHistogram) quantiles() (min, p50, p90, p99, max time.Duration) {
+	h.mu.Lock()
+	samples := make([]time.Duration, len(h.samples))
+	copy(samples, h.samples)
+	h.mu.Unlock()
+
+	if len(samples) == 0 {
+		return 0, 0, 0, 0, 0
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	pct := func(p float64) time.Duration {
+		idx := int(p * float64(len(samples)-1))
+		return samples[idx]
+	}
+	return samples[0], pct(0.50), pct(0.90), pct(0.99), samples[len(samples)-1]
+}
+
+// errorTaxonomy buckets failed requests by cause so a run can be read as
+// "mostly timeouts" vs "mostly DNS" at a glance.
+type errorTaxonomy struct {
+	dns      atomic.Int64
+	connect  atomic.Int64
+	tls      atomic.Int64
+	timeout  atomic.Int64
+	non2xx   atomic.Int64
+	bodyRead atomic.Int64
+	other    atomic.Int64
+}
+
+// classify buckets a transport-level error returned by client.Get. Errors
+// surfaced after a response is received (body reads, status codes) are
+// counted separately by the caller.
+func (e *errorTaxonomy) classify(err error) {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		e.dns.Add(1)
+		return
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		e.timeout.Add(1)
+		return
+	}
+	msg := err.Error()
+	if strings.Contains(msg, "tls:") || strings.Contains(msg, "x509:") {
+		e.tls.Add(1)
+		return
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		e.connect.Add(1)
+		return
+	}
+	e.other.Add(1)
+}
+
+func (e *errorTaxonomy) print() {
+	fmt.Printf("errors: dns=%d connect=%d tls=%d timeout=%d non2xx=%d body-read=%d other=%d\n",
+		e.dns.Load(), e.connect.Load(), e.tls.Load(), e.timeout.Load(),
+		e.non2xx.Load(), e.bodyRead.Load(), e.other.Load())
+}
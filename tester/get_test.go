@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogramQuantiles(t *testing.T) {
+	h := &latencyHistogram{}
+	if min, p50, p90, p99, max := h.quantiles(); min != 0 || p50 != 0 || p90 != 0 || p99 != 0 || max != 0 {
+		t.Fatalf("quantiles() on empty histogram = %v %v %v %v %v, want all zero", min, p50, p90, p99, max)
+	}
+
+	for i := 1; i <= 100; i++ {
+		h.add(time.Duration(i) * time.Millisecond)
+	}
+	min, p50, p90, p99, max := h.quantiles()
+	if min != time.Millisecond {
+		t.Errorf("min = %s, want 1ms", min)
+	}
+	if max != 100*time.Millisecond {
+		t.Errorf("max = %s, want 100ms", max)
+	}
+	if p50 <= min || p50 >= max {
+		t.Errorf("p50 = %s, want strictly between min and max", p50)
+	}
+	if p90 <= p50 || p99 < p90 {
+		t.Errorf("percentiles out of order: p50=%s p90=%s p99=%s", p50, p90, p99)
+	}
+}
+
+func TestErrorTaxonomyClassify(t *testing.T) {
+	e := &errorTaxonomy{}
+
+	e.classify(&net.DNSError{Err: "no such host", Name: "example.invalid"})
+	if e.dns.Load() != 1 {
+		t.Errorf("dns = %d, want 1", e.dns.Load())
+	}
+
+	e.classify(&net.OpError{Op: "dial", Err: errors.New("connection refused")})
+	if e.connect.Load() != 1 {
+		t.Errorf("connect = %d, want 1", e.connect.Load())
+	}
+
+	e.classify(errors.New("tls: handshake failure"))
+	if e.tls.Load() != 1 {
+		t.Errorf("tls = %d, want 1", e.tls.Load())
+	}
+
+	e.classify(errors.New("whatever went wrong"))
+	if e.other.Load() != 1 {
+		t.Errorf("other = %d, want 1", e.other.Load())
+	}
+}
+
+func TestTargetURLs(t *testing.T) {
+	positional := []string{"http://a", "http://b"}
+	urls, err := targetURLs("", positional)
+	if err != nil {
+		t.Fatalf("targetURLs returned error: %v", err)
+	}
+	if len(urls) != 2 || urls[0] != "http://a" || urls[1] != "http://b" {
+		t.Fatalf("targetURLs(no file) = %v, want %v", urls, positional)
+	}
+
+	f, err := os.CreateTemp(t.TempDir(), "urls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("http://c\n\nhttp://d\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	urls, err = targetURLs(f.Name(), nil)
+	if err != nil {
+		t.Fatalf("targetURLs returned error: %v", err)
+	}
+	want := []string{"http://c", "http://d"}
+	if len(urls) != len(want) || urls[0] != want[0] || urls[1] != want[1] {
+		t.Fatalf("targetURLs(file) = %v, want %v (blank lines skipped)", urls, want)
+	}
+}
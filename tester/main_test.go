@@ -1,25 +1,33 @@
 package main
 
-import (
-	"fmt"
-	"testing"
+import "testing"
 
-	"lukechampine.com/blake3"
-)
-
-func TestMakeBlake3TestVectors(t *testing.T) {
-	printVector("")
-	printVector("hello")
-	printVector("minds and machines")
-	printVector("labyrinth")
-	printVector(`There are several levels of meaning which can be read from 
+func TestBlake3Digest(t *testing.T) {
+	inputs := []string{
+		"",
+		"hello",
+		"minds and machines",
+		"labyrinth",
+		`There are several levels of meaning which can be read from
 a strand of DNA, depending on how big the chunks are which you look at,
-and how powerful a decoder you use.`)
+and how powerful a decoder you use.`,
+	}
+	for _, input := range inputs {
+		digest := blake3Digest(input, 32, nil)
+		if len(digest) != 32 {
+			t.Fatalf("blake3Digest(%q) returned %d bytes, want 32", input, len(digest))
+		}
+		if again := blake3Digest(input, 32, nil); string(again) != string(digest) {
+			t.Fatalf("blake3Digest(%q) is not deterministic", input)
+		}
+	}
 }
 
-func printVector(input string) {
-	h := blake3.New(32, nil)
-	h.Write([]byte(input))
-	buf := h.Sum(nil)
-	fmt.Printf("%s->%x\n", input, buf)
+func TestBlake3DigestKeyed(t *testing.T) {
+	key := make([]byte, 32)
+	unkeyed := blake3Digest("hello", 32, nil)
+	keyed := blake3Digest("hello", 32, key)
+	if string(unkeyed) == string(keyed) {
+		t.Fatalf("keyed and unkeyed digests should differ")
+	}
 }
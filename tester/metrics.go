@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ResponseWriter wraps an http.ResponseWriter to capture the status code
+// and byte count written. It implements Unwrap so that handlers further
+// down the chain that need Hijacker/Flusher access go through
+// http.NewResponseController, which only advertises those capabilities
+// when the underlying writer actually has them, rather than this wrapper
+// claiming to support them unconditionally.
+type ResponseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *ResponseWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *ResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap returns the underlying ResponseWriter, letting
+// http.NewResponseController see through to its real Hijacker/Flusher
+// support instead of this wrapper's.
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// idleRouteTTL is how long a route's metrics may sit unused before the
+// registry reclaims them. Instrument is typically mounted on a catch-all
+// pattern, so routes are keyed by the raw request path; without this GC,
+// a scanner hitting arbitrary paths would grow the map without bound, the
+// same exposure idleLimiterTTL guards against for per-client limiters.
+const idleRouteTTL = 10 * time.Minute
+
+// routeMetrics holds the counters and latency samples for a single route.
+type routeMetrics struct {
+	requests  atomic.Int64
+	inFlight  atomic.Int64
+	bytesOut  atomic.Int64
+	latencies latencyHistogram
+	lastSeen  atomic.Int64 // unix nanos
+}
+
+// metricsRegistry tracks per-route metrics and serves them in Prometheus
+// text-exposition format.
+type metricsRegistry struct {
+	mu     sync.Mutex
+	routes map[string]*routeMetrics
+}
+
+func newMetricsRegistry() *metricsRegistry {
+	m := &metricsRegistry{routes: make(map[string]*routeMetrics)}
+	go m.gcLoop()
+	return m
+}
+
+func (m *metricsRegistry) route(path string) *routeMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rm, ok := m.routes[path]
+	if !ok {
+		rm = &routeMetrics{}
+		m.routes[path] = rm
+	}
+	rm.lastSeen.Store(time.Now().UnixNano())
+	return rm
+}
+
+func (m *metricsRegistry) gcLoop() {
+	for {
+		time.Sleep(time.Minute)
+		m.mu.Lock()
+		for path, rm := range m.routes {
+			if time.Since(time.Unix(0, rm.lastSeen.Load())) > idleRouteTTL {
+				delete(m.routes, path)
+			}
+		}
+		m.mu.Unlock()
+	}
+}
+
+// Instrument wraps next, recording per-route request counts, an in-flight
+// gauge, byte totals, and latencies into reg.
+func Instrument(next http.Handler, reg *metricsRegistry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rm := reg.route(r.URL.Path)
+		rm.inFlight.Add(1)
+		defer rm.inFlight.Add(-1)
+
+		start := time.Now()
+		mw := &ResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(mw, r)
+
+		rm.requests.Add(1)
+		rm.bytesOut.Add(mw.bytes)
+		rm.latencies.add(time.Since(start))
+	})
+}
+
+// ServeHTTP renders the registry's counters in Prometheus text-exposition
+// format.
+func (m *metricsRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	paths := make([]string, 0, len(m.routes))
+	routes := make(map[string]*routeMetrics, len(m.routes))
+	for p, rm := range m.routes {
+		paths = append(paths, p)
+		routes[p] = rm
+	}
+	m.mu.Unlock()
+	sort.Strings(paths)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	fmt.Fprintln(w, "# HELP peregrine_requests_total Total requests handled, by route.")
+	fmt.Fprintln(w, "# TYPE peregrine_requests_total counter")
+	for _, p := range paths {
+		fmt.Fprintf(w, "peregrine_requests_total{route=%q} %d\n", p, routes[p].requests.Load())
+	}
+
+	fmt.Fprintln(w, "# HELP peregrine_requests_in_flight Requests currently being handled, by route.")
+	fmt.Fprintln(w, "# TYPE peregrine_requests_in_flight gauge")
+	for _, p := range paths {
+		fmt.Fprintf(w, "peregrine_requests_in_flight{route=%q} %d\n", p, routes[p].inFlight.Load())
+	}
+
+	fmt.Fprintln(w, "# HELP peregrine_response_bytes_total Total response bytes written, by route.")
+	fmt.Fprintln(w, "# TYPE peregrine_response_bytes_total counter")
+	for _, p := range paths {
+		fmt.Fprintf(w, "peregrine_response_bytes_total{route=%q} %d\n", p, routes[p].bytesOut.Load())
+	}
+
+	fmt.Fprintln(w, "# HELP peregrine_request_duration_seconds Request latency, by route.")
+	fmt.Fprintln(w, "# TYPE peregrine_request_duration_seconds summary")
+	for _, p := range paths {
+		rm := routes[p]
+		min, p50, p90, p99, max := rm.latencies.quantiles()
+		fmt.Fprintf(w, "peregrine_request_duration_seconds{route=%q,quantile=\"0\"} %f\n", p, min.Seconds())
+		fmt.Fprintf(w, "peregrine_request_duration_seconds{route=%q,quantile=\"0.5\"} %f\n", p, p50.Seconds())
+		fmt.Fprintf(w, "peregrine_request_duration_seconds{route=%q,quantile=\"0.9\"} %f\n", p, p90.Seconds())
+		fmt.Fprintf(w, "peregrine_request_duration_seconds{route=%q,quantile=\"0.99\"} %f\n", p, p99.Seconds())
+		fmt.Fprintf(w, "peregrine_request_duration_seconds{route=%q,quantile=\"1\"} %f\n", p, max.Seconds())
+		fmt.Fprintf(w, "peregrine_request_duration_seconds_count{route=%q} %d\n", p, rm.requests.Load())
+	}
+}
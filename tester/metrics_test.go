@@ -0,0 +1,105 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseWriterCapturesStatusAndBytes(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &ResponseWriter{ResponseWriter: rec}
+
+	w.WriteHeader(http.StatusTeapot)
+	n, err := w.Write([]byte("hello"))
+	if err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if n != 5 {
+		t.Errorf("Write returned n=%d, want 5", n)
+	}
+	if w.status != http.StatusTeapot {
+		t.Errorf("status = %d, want %d", w.status, http.StatusTeapot)
+	}
+	if w.bytes != 5 {
+		t.Errorf("bytes = %d, want 5", w.bytes)
+	}
+}
+
+func TestResponseWriterDefaultsStatusOnWrite(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &ResponseWriter{ResponseWriter: rec}
+	if _, err := w.Write([]byte("x")); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	if w.status != http.StatusOK {
+		t.Errorf("status = %d, want %d when WriteHeader was never called", w.status, http.StatusOK)
+	}
+}
+
+func TestResponseWriterPassesThroughFlusher(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &ResponseWriter{ResponseWriter: rec}
+	if _, ok := http.ResponseWriter(w).(http.Flusher); ok {
+		t.Fatal("ResponseWriter must not statically implement http.Flusher")
+	}
+	if err := http.NewResponseController(w).Flush(); err != nil {
+		t.Fatalf("Flush() via ResponseController returned error: %v", err)
+	}
+	if !rec.Flushed {
+		t.Errorf("Flush() did not propagate to the underlying ResponseWriter")
+	}
+}
+
+// nonFlushingWriter implements only http.ResponseWriter, to verify that
+// ResponseWriter doesn't claim Flusher support it doesn't have.
+type nonFlushingWriter struct {
+	http.ResponseWriter
+}
+
+func TestResponseWriterDoesNotClaimUnsupportedFlush(t *testing.T) {
+	w := &ResponseWriter{ResponseWriter: &nonFlushingWriter{httptest.NewRecorder()}}
+	if err := http.NewResponseController(w).Flush(); err == nil {
+		t.Error("Flush() via ResponseController should error when the underlying writer isn't a Flusher")
+	}
+}
+
+func TestInstrumentRecordsRequests(t *testing.T) {
+	reg := newMetricsRegistry()
+	handler := Instrument(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hi"))
+	}), reg)
+
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	rm := reg.route("/hello")
+	if rm.requests.Load() != 2 {
+		t.Errorf("requests = %d, want 2", rm.requests.Load())
+	}
+	if rm.bytesOut.Load() != 4 {
+		t.Errorf("bytesOut = %d, want 4", rm.bytesOut.Load())
+	}
+	if rm.inFlight.Load() != 0 {
+		t.Errorf("inFlight = %d, want 0 after requests complete", rm.inFlight.Load())
+	}
+}
+
+func TestMetricsRegistryServeHTTPFormat(t *testing.T) {
+	reg := newMetricsRegistry()
+	reg.route("/hello").requests.Add(3)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	reg.ServeHTTP(rec, req)
+
+	body := rec.Body.String()
+	if !strings.Contains(body, `peregrine_requests_total{route="/hello"} 3`) {
+		t.Errorf("metrics output missing expected requests_total line, got:\n%s", body)
+	}
+	if !strings.Contains(body, "# TYPE peregrine_request_duration_seconds summary") {
+		t.Errorf("metrics output missing duration summary TYPE line, got:\n%s", body)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// idleLimiterTTL is how long a per-client limiter may sit unused before
+// clientLimiters reclaims it. Scan-style traffic from many short-lived
+// IPs would otherwise grow the map without bound.
+const idleLimiterTTL = 10 * time.Minute
+
+// clientLimiters hands out a token-bucket rate.Limiter per client IP,
+// creating one on first sight and periodically garbage-collecting entries
+// that have gone idle.
+type clientLimiters struct {
+	mu      sync.Mutex
+	clients map[string]*clientLimiter
+	rps     float64
+	burst   int
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newClientLimiters(rps float64, burst int) *clientLimiters {
+	l := &clientLimiters{
+		clients: make(map[string]*clientLimiter),
+		rps:     rps,
+		burst:   burst,
+	}
+	go l.gcLoop()
+	return l
+}
+
+func (l *clientLimiters) get(ip string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	cl, ok := l.clients[ip]
+	if !ok {
+		cl = &clientLimiter{limiter: rate.NewLimiter(rate.Limit(l.rps), l.burst)}
+		l.clients[ip] = cl
+	}
+	cl.lastSeen = time.Now()
+	return cl.limiter
+}
+
+func (l *clientLimiters) gcLoop() {
+	for {
+		time.Sleep(time.Minute)
+		l.mu.Lock()
+		for ip, cl := range l.clients {
+			if time.Since(cl.lastSeen) > idleLimiterTTL {
+				delete(l.clients, ip)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+// rateLimitMiddleware rejects requests once a client IP exceeds its
+// token-bucket allowance, responding 429 with a Retry-After header. trustProxy
+// must only be set when the server sits behind a proxy that overwrites
+// X-Forwarded-For itself; otherwise clients can forge the header to get a
+// fresh bucket per request and bypass the limit entirely.
+func rateLimitMiddleware(next http.Handler, limiters *clientLimiters, trustProxy bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !limiters.get(clientIP(r, trustProxy)).Allow() {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "429 Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP extracts the client's RemoteAddr. When trustProxy is set, it
+// instead takes the originating address from X-Forwarded-For, which is only
+// safe when a trusted proxy in front of this server sets that header itself
+// rather than passing through whatever the client sent.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			if i := strings.Index(fwd, ","); i >= 0 {
+				fwd = fwd[:i]
+			}
+			return strings.TrimSpace(fwd)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimitBurstBytes bounds how many bytes a single WaitN call ever asks
+// the limiter for. It is independent of the configured rate: rate.Limiter
+// rejects any WaitN(n) where n exceeds the limiter's burst instead of
+// waiting for it, so writes are chunked to this size rather than sized to
+// bytesPerSec.
+const rateLimitBurstBytes = 32 * 1024
+
+// byteRateLimitMiddleware caps each response's byte-rate, a 5 Mbps-style
+// guard like the tailscale derper's to stop a slow consumer from
+// saturating the link. Each request gets its own limiter so one client's
+// writes can't throttle or contend with another's; concurrent requests on
+// one connection (e.g. HTTP/2 streams) are each limited separately.
+func byteRateLimitMiddleware(next http.Handler, bytesPerSec float64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter := rate.NewLimiter(rate.Limit(bytesPerSec), rateLimitBurstBytes)
+		next.ServeHTTP(&rateLimitedWriter{ResponseWriter: w, limiter: limiter}, r)
+	})
+}
+
+// rateLimitedWriter throttles Write calls against its request's byte-rate
+// limiter, blocking as needed before writing each chunk. It propagates
+// Hijacker, Flusher, and Pusher to the wrapped writer through its own
+// methods so it stays transparent to websocket/SSE upgrades; unlike
+// ResponseWriter, which exposes the wrapped writer via Unwrap instead.
+type rateLimitedWriter struct {
+	http.ResponseWriter
+	limiter *rate.Limiter
+}
+
+func (w *rateLimitedWriter) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		end := written + rateLimitBurstBytes
+		if end > len(p) {
+			end = len(p)
+		}
+		if err := w.limiter.WaitN(context.Background(), end-written); err != nil {
+			return written, err
+		}
+		n, err := w.ResponseWriter.Write(p[written:end])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+func (w *rateLimitedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("underlying ResponseWriter does not implement http.Hijacker")
+	}
+	return h.Hijack()
+}
+
+func (w *rateLimitedWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *rateLimitedWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
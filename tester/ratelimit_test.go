@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+func TestClientLimitersReusesPerIP(t *testing.T) {
+	l := newClientLimiters(1, 1)
+	a := l.get("1.2.3.4")
+	b := l.get("1.2.3.4")
+	if a != b {
+		t.Fatalf("get() returned different limiters for the same IP")
+	}
+	c := l.get("5.6.7.8")
+	if a == c {
+		t.Fatalf("get() returned the same limiter for different IPs")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	if ip := clientIP(r, false); ip != "10.0.0.1" {
+		t.Errorf("clientIP() = %q, want 10.0.0.1", ip)
+	}
+
+	r.Header.Set("X-Forwarded-For", "203.0.113.5, 10.0.0.1")
+	if ip := clientIP(r, false); ip != "10.0.0.1" {
+		t.Errorf("clientIP() without trustProxy = %q, want 10.0.0.1 (X-Forwarded-For must be ignored)", ip)
+	}
+	if ip := clientIP(r, true); ip != "203.0.113.5" {
+		t.Errorf("clientIP() with trustProxy = %q, want 203.0.113.5", ip)
+	}
+}
+
+func TestRateLimitMiddlewareRejectsOverLimit(t *testing.T) {
+	limiters := newClientLimiters(0, 1)
+	handler := rateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), limiters, false)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:1"
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want 200", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Errorf("429 response missing Retry-After header")
+	}
+}
+
+// TestRateLimitedWriterExceedsBurst guards against the WaitN(n) > burst
+// bug: writing more bytes than the limiter's burst in one call must be
+// throttled in chunks, not fail outright.
+func TestRateLimitedWriterExceedsBurst(t *testing.T) {
+	limiter := rate.NewLimiter(rate.Limit(1<<20), rateLimitBurstBytes)
+	rec := httptest.NewRecorder()
+	w := &rateLimitedWriter{ResponseWriter: rec, limiter: limiter}
+
+	p := make([]byte, rateLimitBurstBytes*3+7)
+	n, err := w.Write(p)
+	if err != nil {
+		t.Fatalf("Write returned error for payload larger than burst: %v", err)
+	}
+	if n != len(p) {
+		t.Fatalf("Write returned n=%d, want %d", n, len(p))
+	}
+	if rec.Body.Len() != len(p) {
+		t.Fatalf("underlying writer received %d bytes, want %d", rec.Body.Len(), len(p))
+	}
+}
+
+func TestRateLimitedWriterPassesThroughFlusher(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &rateLimitedWriter{ResponseWriter: rec, limiter: rate.NewLimiter(rate.Inf, rateLimitBurstBytes)}
+	f, ok := http.ResponseWriter(w).(http.Flusher)
+	if !ok {
+		t.Fatal("rateLimitedWriter does not implement http.Flusher")
+	}
+	f.Flush()
+	if !rec.Flushed {
+		t.Errorf("Flush() did not propagate to the underlying ResponseWriter")
+	}
+}
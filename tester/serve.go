@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// serve drives the "serve" subcommand: a minimal HTTP handler used as a
+// benchmarking target. By default it binds plain HTTP, but can be pointed
+// at a certificate pair or told to obtain one itself via ACME so that
+// `get` can be run against realistic HTTPS endpoints.
+func serve(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "", "address to listen on (default :3000, or :443 with -acme)")
+	certdir := fs.String("certdir", "certs", "directory to cache ACME certificates in")
+	hostname := fs.String("hostname", "", "hostname to request an ACME certificate for")
+	acme := fs.Bool("acme", false, "obtain a TLS certificate via ACME/Let's Encrypt")
+	cert := fs.String("cert", "", "TLS certificate file")
+	key := fs.String("key", "", "TLS key file")
+	noHTTP2 := fs.Bool("no-http2", false, "disable HTTP/2, serving HTTP/1.1 only")
+	redirectHTTP := fs.Bool("redirect-http", false, "run a second listener on :80 redirecting to https")
+	rps := fs.Float64("rps", 0, "per-client requests/sec limit, 0 disables")
+	burst := fs.Int("burst", 1, "per-client burst size for -rps")
+	trustProxy := fs.Bool("trust-proxy", false, "trust X-Forwarded-For for -rps client keying; only set this behind a proxy that overwrites the header itself")
+	rateMbps := fs.Float64("rate-mbps", 0, "per-response byte-rate limit in megabits/sec, 0 disables")
+	fs.Parse(args)
+
+	if *addr == "" {
+		if *acme {
+			// ACME's TLS-ALPN-01/HTTP-01 challenges reach the host on
+			// :443/:80, so the plain-HTTP default would make issuance
+			// fail silently.
+			*addr = ":443"
+		} else {
+			*addr = ":3000"
+		}
+	}
+
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Hello world"))
+	})
+	if *rateMbps > 0 {
+		handler = byteRateLimitMiddleware(handler, *rateMbps*1_000_000/8)
+	}
+	if *rps > 0 {
+		handler = rateLimitMiddleware(handler, newClientLimiters(*rps, *burst), *trustProxy)
+	}
+
+	metrics := newMetricsRegistry()
+	mux := http.NewServeMux()
+	mux.Handle("/", Instrument(handler, metrics))
+	mux.Handle("/metrics", metrics)
+
+	srv := &http.Server{
+		Addr:    *addr,
+		Handler: mux,
+	}
+	if *noHTTP2 {
+		srv.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+
+	var err error
+	switch {
+	case *acme:
+		if *hostname == "" {
+			fmt.Println("-acme requires -hostname")
+			return
+		}
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(*hostname),
+			Cache:      autocert.DirCache(*certdir),
+		}
+		srv.TLSConfig = m.TLSConfig()
+		if *noHTTP2 {
+			// autocert advertises h2 via ALPN; with TLSNextProto emptied
+			// the server would drop any connection that negotiates it.
+			protos := make([]string, 0, len(srv.TLSConfig.NextProtos))
+			for _, p := range srv.TLSConfig.NextProtos {
+				if p != "h2" {
+					protos = append(protos, p)
+				}
+			}
+			srv.TLSConfig.NextProtos = protos
+		}
+		if *redirectHTTP {
+			go runRedirectServer(m)
+		}
+		err = srv.ListenAndServeTLS("", "")
+	case *cert != "" && *key != "":
+		if *redirectHTTP {
+			go runRedirectServer(nil)
+		}
+		err = srv.ListenAndServeTLS(*cert, *key)
+	default:
+		err = srv.ListenAndServe()
+	}
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// runRedirectServer listens on :80 and redirects everything to https. When
+// m is non-nil, ACME HTTP-01 challenge requests are served directly so the
+// manager in the main TLS listener can complete certificate issuance.
+func runRedirectServer(m *autocert.Manager) {
+	var handler http.Handler = http.HandlerFunc(redirectToHTTPS)
+	if m != nil {
+		handler = m.HTTPHandler(http.HandlerFunc(redirectToHTTPS))
+	}
+	if err := http.ListenAndServe(":80", handler); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// redirectToHTTPS sends a permanent redirect to the https equivalent of
+// the request's host and path.
+func redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	target := "https://" + r.Host + r.URL.RequestURI()
+	http.Redirect(w, r, target, http.StatusMovedPermanently)
+}